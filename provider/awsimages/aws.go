@@ -1,7 +1,8 @@
 package awsimages
 
 import (
-	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"os"
 	"sort"
@@ -9,8 +10,9 @@ import (
 	"time"
 
 	"github.com/awslabs/aws-sdk-go/aws"
-	"github.com/awslabs/aws-sdk-go/aws/credentials"
+	"github.com/awslabs/aws-sdk-go/aws/session"
 	"github.com/awslabs/aws-sdk-go/service/ec2"
+	"github.com/awslabs/aws-sdk-go/service/ec2/ec2iface"
 	"github.com/hashicorp/go-multierror"
 )
 
@@ -19,27 +21,74 @@ type AwsConfig struct {
 	RegionExclude string `toml:"region_exclude" json:"region_exclude"`
 	AccessKey     string `toml:"access_key" json:"access_key"`
 	SecretKey     string `toml:"secret_key" json:"secret_key"`
+
+	// Profile is a named profile in ~/.aws/credentials, used when
+	// AccessKey/SecretKey are empty.
+	Profile string `toml:"profile" json:"profile"`
+
+	// AssumeRoleARN, if set, is assumed after the base credential chain
+	// resolves, using ExternalID/SessionName/MFASerial/MFACode as needed.
+	AssumeRoleARN string `toml:"assume_role_arn" json:"assume_role_arn"`
+	ExternalID    string `toml:"external_id" json:"external_id"`
+	SessionName   string `toml:"session_name" json:"session_name"`
+	MFASerial     string `toml:"mfa_serial" json:"mfa_serial"`
+	MFACode       string `toml:"mfa_code" json:"mfa_code"`
+
+	// SkipMetadataAPICheck disables the EC2 instance metadata credential
+	// provider, so the chain doesn't pay its timeout when running off EC2.
+	SkipMetadataAPICheck bool `toml:"skip_metadata_api_check" json:"skip_metadata_api_check"`
+
+	// AllowPartialRegions controls what happens when a single region can't
+	// be reached: false (the default, and what every config predating this
+	// field gets) aborts the whole operation by folding the failure into
+	// the returned multierror, matching pre-multi-region behavior. true
+	// downgrades it to a logged warning so the other regions' results
+	// still come back.
+	AllowPartialRegions bool `toml:"allow_partial_regions" json:"allow_partial_regions"`
+
+	// MaxRetries, BaseDelay and MaxDelay tune the exponential backoff
+	// retry applied to throttled/transient per-region API calls. Zero
+	// values fall back to package defaults.
+	MaxRetries int           `toml:"max_retries" json:"max_retries"`
+	BaseDelay  time.Duration `toml:"base_delay" json:"base_delay"`
+	MaxDelay   time.Duration `toml:"max_delay" json:"max_delay"`
 }
 
 // AwsImages is responsible of managing AWS images (AMI's)
 type AwsImages struct {
+	conf     *AwsConfig
 	services *multiRegion
 	images   MultiImages
+
+	// regions caches the region list resolved by parseRegions, so repeated
+	// operations don't need to re-run region discovery/glob matching.
+	regions []string
+
+	// ec2Factory overrides how EC2 clients are built per region. Set via
+	// WithEC2Factory so tests can substitute fakes instead of hitting AWS.
+	ec2Factory ec2Factory
 }
 
-func New(conf *AwsConfig) (*AwsImages, error) {
-	checkCfg := "Please check your configuration"
+// Option customizes an AwsImages returned by New.
+type Option func(*AwsImages)
 
-	if conf.Region == "" {
-		return nil, errors.New("AWS Region is not set. " + checkCfg)
+// WithEC2Factory overrides how AwsImages builds its per-region EC2 clients,
+// mirroring Packer's AccessConfig.getEC2Connection hook. It exists so unit
+// tests can substitute fakes without hitting real AWS.
+func WithEC2Factory(factory func(region string) ec2iface.EC2API) Option {
+	return func(a *AwsImages) {
+		a.ec2Factory = factory
 	}
+}
 
-	if conf.AccessKey == "" {
-		return nil, errors.New("AWS Access Key is not set. " + checkCfg)
+func New(conf *AwsConfig, opts ...Option) (*AwsImages, error) {
+	a := &AwsImages{
+		conf:   conf,
+		images: make(map[string][]*ec2.Image),
 	}
 
-	if conf.SecretKey == "" {
-		return nil, errors.New("AWS Secret Key is not set. " + checkCfg)
+	for _, opt := range opts {
+		opt(a)
 	}
 
 	// increase the timeout
@@ -49,20 +98,46 @@ func New(conf *AwsConfig) (*AwsImages, error) {
 		Timeout:   timeout,
 	}
 
-	creds := credentials.NewStaticCredentials(conf.AccessKey, conf.SecretKey, "")
+	sess := session.New(&aws.Config{
+		HTTPClient: client,
+		Logger:     os.Stdout,
+	})
+
 	awsCfg := &aws.Config{
-		Credentials: creds,
+		Credentials: resolveCredentials(conf, sess),
 		HTTPClient:  client,
 		Logger:      os.Stdout,
 	}
 
-	m := newMultiRegion(awsCfg, parseRegions(conf.Region, conf.RegionExclude))
-	return &AwsImages{
-		services: m,
-		images:   make(map[string][]*ec2.Image),
-	}, nil
+	factory := func(region string) ec2iface.EC2API {
+		return a.getEC2Connection(awsCfg, region)
+	}
+
+	regions, err := parseRegions(factory, conf.Region, conf.RegionExclude)
+	if err != nil {
+		return nil, err
+	}
+
+	a.services = newMultiRegion(regions, factory)
+	a.regions = regions
+
+	return a, nil
+}
+
+// getEC2Connection returns the EC2 client to use for region: the injected
+// ec2Factory if WithEC2Factory was given, otherwise a real client built
+// from cfg.
+func (a *AwsImages) getEC2Connection(cfg *aws.Config, region string) ec2iface.EC2API {
+	if a.ec2Factory != nil {
+		return a.ec2Factory(region)
+	}
+
+	sess := session.New(cfg)
+	return ec2.New(sess, &aws.Config{Region: aws.String(region)})
 }
 
+// MultiImages fans out a paginated, retried DescribeImages across every
+// configured region and buffers the full result.
 func (a *AwsImages) MultiImages(input *ec2.DescribeImagesInput) (MultiImages, error) {
 	var (
 		wg sync.WaitGroup
@@ -75,23 +150,33 @@ func (a *AwsImages) MultiImages(input *ec2.DescribeImagesInput) (MultiImages, er
 
 	for r, s := range a.services.regions {
 		wg.Add(1)
-		go func(region string, svc *ec2.EC2) {
-			resp, err := svc.DescribeImages(input)
+		go func(region string, svc ec2iface.EC2API) {
+			defer wg.Done()
+
+			var regionImages []*ec2.Image
+			err := describeImagesPaged(a.conf, svc, input, func(page *ec2.DescribeImagesOutput) error {
+				regionImages = append(regionImages, page.Images...)
+				return nil
+			})
+
 			mu.Lock()
+			defer mu.Unlock()
 
 			if err != nil {
-				multiErrors = multierror.Append(multiErrors, err)
-			} else {
-				// sort from oldest to newest
-				if len(resp.Images) > 1 {
-					sort.Sort(byTime(resp.Images))
+				if a.conf.AllowPartialRegions {
+					log.Printf("[WARN] region %s: %s", region, err)
+				} else {
+					multiErrors = multierror.Append(multiErrors, fmt.Errorf("region %s: %s", region, err))
 				}
+				return
+			}
 
-				images[region] = resp.Images
+			// sort from oldest to newest
+			if len(regionImages) > 1 {
+				sort.Sort(byTime(regionImages))
 			}
 
-			mu.Unlock()
-			wg.Done()
+			images[region] = regionImages
 		}(r, s)
 	}
 
@@ -100,6 +185,99 @@ func (a *AwsImages) MultiImages(input *ec2.DescribeImagesInput) (MultiImages, er
 	return images, multiErrors
 }
 
+// MultiImagesStream fans out the same paginated, retried DescribeImages as
+// MultiImages, but calls fn as each page arrives instead of buffering the
+// whole result, so callers like list/delete can start work before the
+// slowest region finishes. fn is invoked concurrently from each region's
+// goroutine and must be safe for that; an error from fn stops that region's
+// pagination early and is folded into the returned multierror the same way
+// a region's own DescribeImages failure would be.
+func (a *AwsImages) MultiImagesStream(input *ec2.DescribeImagesInput, fn func(region string, img *ec2.Image) error) error {
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+
+		multiErrors error
+	)
+
+	for r, s := range a.services.regions {
+		wg.Add(1)
+		go func(region string, svc ec2iface.EC2API) {
+			defer wg.Done()
+
+			err := describeImagesPaged(a.conf, svc, input, func(page *ec2.DescribeImagesOutput) error {
+				for _, img := range page.Images {
+					if err := fn(region, img); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+
+			if err == nil {
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if a.conf.AllowPartialRegions {
+				log.Printf("[WARN] region %s: %s", region, err)
+			} else {
+				multiErrors = multierror.Append(multiErrors, fmt.Errorf("region %s: %s", region, err))
+			}
+		}(r, s)
+	}
+
+	wg.Wait()
+
+	return multiErrors
+}
+
+// describeImagesPaged runs input through svc's DescribeImages paginator,
+// calling onPage for every page, with the whole paginated call retried via
+// withRetry on throttling/transient errors. A page-level retry restarts the
+// paginator from page 1, so images already handed to onPage are tracked by
+// id and skipped on replay, meaning onPage never sees the same image twice.
+func describeImagesPaged(conf *AwsConfig, svc ec2iface.EC2API, input *ec2.DescribeImagesInput, onPage func(*ec2.DescribeImagesOutput) error) error {
+	var pageErr error
+	seen := make(map[string]bool)
+
+	err := withRetry(conf, func() error {
+		pageErr = nil
+
+		return svc.DescribeImagesPages(input, func(page *ec2.DescribeImagesOutput, lastPage bool) bool {
+			fresh := make([]*ec2.Image, 0, len(page.Images))
+			for _, img := range page.Images {
+				if img.ImageId == nil || seen[*img.ImageId] {
+					continue
+				}
+
+				seen[*img.ImageId] = true
+				fresh = append(fresh, img)
+			}
+
+			if len(fresh) == 0 {
+				return true
+			}
+
+			if err := onPage(&ec2.DescribeImagesOutput{Images: fresh}); err != nil {
+				pageErr = err
+				return false
+			}
+
+			return true
+		})
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return pageErr
+}
+
 func (a *AwsImages) ownerImages() (MultiImages, error) {
 	input := &ec2.DescribeImagesInput{
 		Owners: stringSlice("self"),
@@ -115,8 +293,16 @@ func (a *AwsImages) Help(command string) string {
 	global := `
   -access-key      "..."       AWS Access Key (env: IMAGES_AWS_ACCESS_KEY)
   -secret-key      "..."       AWS Secret Key (env: IMAGES_AWS_SECRET_KEY)
-  -region          "..."       AWS Region (env: IMAGES_AWS_REGION)
-  -region-exclude  "..."       AWS Region to be excluded (env: IMAGES_AWS_REGION_EXCLUDE)
+  -profile         "..."       AWS shared credentials profile (env: IMAGES_AWS_PROFILE)
+  -assume-role-arn "..."       IAM role to assume after resolving credentials (env: IMAGES_AWS_ASSUME_ROLE_ARN)
+  -external-id     "..."       External ID to use when assuming the role (env: IMAGES_AWS_EXTERNAL_ID)
+  -skip-metadata-api-check     Don't try the EC2 instance metadata credential provider
+  -region          "..."       AWS Region, "all", or a glob such as "us-*" (env: IMAGES_AWS_REGION)
+  -region-exclude  "..."       AWS Region or glob to be excluded (env: IMAGES_AWS_REGION_EXCLUDE)
+  -allow-partial-regions       Warn and continue if a region is unreachable, instead of aborting
+  -max-retries     "..."       Max retries for throttled/transient API calls (env: IMAGES_AWS_MAX_RETRIES)
+  -base-delay      "..."       Base retry backoff delay, e.g. "200ms" (env: IMAGES_AWS_BASE_DELAY)
+  -max-delay       "..."       Max retry backoff delay, e.g. "30s" (env: IMAGES_AWS_MAX_DELAY)
 `
 	switch command {
 	case "modify":
@@ -0,0 +1,196 @@
+package awsimages
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/awslabs/aws-sdk-go/aws"
+	"github.com/awslabs/aws-sdk-go/aws/awserr"
+	"github.com/awslabs/aws-sdk-go/service/ec2"
+	"github.com/awslabs/aws-sdk-go/service/ec2/ec2iface"
+)
+
+// fakeEC2 implements ec2iface.EC2API, overriding only DescribeImages and
+// DescribeImagesPages so tests don't have to stub the entire interface.
+type fakeEC2 struct {
+	ec2iface.EC2API
+
+	images []*ec2.Image
+	err    error
+}
+
+func (f *fakeEC2) DescribeImages(input *ec2.DescribeImagesInput) (*ec2.DescribeImagesOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	return &ec2.DescribeImagesOutput{Images: f.images}, nil
+}
+
+func (f *fakeEC2) DescribeImagesPages(input *ec2.DescribeImagesInput, fn func(*ec2.DescribeImagesOutput, bool) bool) error {
+	if f.err != nil {
+		return f.err
+	}
+
+	fn(&ec2.DescribeImagesOutput{Images: f.images}, true)
+	return nil
+}
+
+// pagingFakeEC2 serves DescribeImagesPages out of a fixed set of pages,
+// failing once on failPage with err before ever delivering it, so tests can
+// exercise a page-level retry.
+type pagingFakeEC2 struct {
+	ec2iface.EC2API
+
+	pages    [][]*ec2.Image
+	failPage int
+	err      awserr.Error
+
+	failed bool
+}
+
+func (f *pagingFakeEC2) DescribeImagesPages(input *ec2.DescribeImagesInput, fn func(*ec2.DescribeImagesOutput, bool) bool) error {
+	for i, page := range f.pages {
+		if i == f.failPage && !f.failed {
+			f.failed = true
+			return f.err
+		}
+
+		if !fn(&ec2.DescribeImagesOutput{Images: page}, i == len(f.pages)-1) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func newTestImages(regions map[string]*fakeEC2, allowPartialRegions bool) *AwsImages {
+	services := &multiRegion{regions: make(map[string]ec2iface.EC2API, len(regions))}
+	for region, svc := range regions {
+		services.regions[region] = svc
+	}
+
+	return &AwsImages{
+		conf:     &AwsConfig{AllowPartialRegions: allowPartialRegions},
+		services: services,
+		images:   make(map[string][]*ec2.Image),
+	}
+}
+
+func testImage(id, createdAt string) *ec2.Image {
+	return &ec2.Image{
+		ImageId:      aws.String(id),
+		CreationDate: aws.String(createdAt),
+	}
+}
+
+func TestMultiImages(t *testing.T) {
+	cases := []struct {
+		name         string
+		regions      map[string]*fakeEC2
+		allowPartial bool
+		want         map[string]int
+		wantErr      bool
+	}{
+		{
+			name: "sorts images oldest to newest",
+			regions: map[string]*fakeEC2{
+				"us-east-1": {images: []*ec2.Image{
+					testImage("ami-2", "2020-02-01T00:00:00.000Z"),
+					testImage("ami-1", "2020-01-01T00:00:00.000Z"),
+				}},
+			},
+			want: map[string]int{"us-east-1": 2},
+		},
+		{
+			name: "empty response region",
+			regions: map[string]*fakeEC2{
+				"us-west-2": {images: nil},
+			},
+			want: map[string]int{"us-west-2": 0},
+		},
+		{
+			name: "partial region failure aborts by default",
+			regions: map[string]*fakeEC2{
+				"us-east-1": {images: []*ec2.Image{testImage("ami-1", "2020-01-01T00:00:00.000Z")}},
+				"eu-west-1": {err: errors.New("boom")},
+			},
+			wantErr: true,
+		},
+		{
+			name: "partial region failure is downgraded to a warning when AllowPartialRegions is set",
+			regions: map[string]*fakeEC2{
+				"us-east-1": {images: []*ec2.Image{testImage("ami-1", "2020-01-01T00:00:00.000Z")}},
+				"eu-west-1": {err: errors.New("boom")},
+			},
+			allowPartial: true,
+			want:         map[string]int{"us-east-1": 1},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			a := newTestImages(c.regions, c.allowPartial)
+
+			got, err := a.MultiImages(&ec2.DescribeImagesInput{})
+
+			if c.wantErr {
+				if err == nil {
+					t.Fatal("expected an error, got none")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+
+			for region, count := range c.want {
+				if len(got[region]) != count {
+					t.Errorf("region %s: got %d images, want %d", region, len(got[region]), count)
+				}
+			}
+
+			if images := got["us-east-1"]; len(images) == 2 {
+				if *images[0].ImageId != "ami-1" {
+					t.Errorf("expected oldest image first, got %s", *images[0].ImageId)
+				}
+			}
+		})
+	}
+}
+
+func TestDescribeImagesPagedRetryDoesNotDuplicatePages(t *testing.T) {
+	fake := &pagingFakeEC2{
+		pages: [][]*ec2.Image{
+			{testImage("ami-1", "2020-01-01T00:00:00.000Z")},
+			{testImage("ami-2", "2020-01-02T00:00:00.000Z")},
+		},
+		failPage: 1,
+		err:      awserr.New("Throttling", "slow down", nil),
+	}
+
+	conf := &AwsConfig{BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+	var got []*ec2.Image
+	err := describeImagesPaged(conf, fake, &ec2.DescribeImagesInput{}, func(page *ec2.DescribeImagesOutput) error {
+		got = append(got, page.Images...)
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	seen := make(map[string]int)
+	for _, img := range got {
+		seen[*img.ImageId]++
+	}
+
+	for _, id := range []string{"ami-1", "ami-2"} {
+		if seen[id] != 1 {
+			t.Errorf("image %s delivered %d times, want exactly 1", id, seen[id])
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package awsimages
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/awslabs/aws-sdk-go/aws"
+	"github.com/awslabs/aws-sdk-go/service/ec2"
+	"github.com/awslabs/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/hashicorp/go-multierror"
+)
+
+// copyPollInterval is how often we re-check an in-flight copy's state when
+// CopyInput.WaitForCompletion is set.
+const copyPollInterval = 10 * time.Second
+
+// CopyInput describes an AMI copy operation from a single source region to
+// one or more target regions.
+type CopyInput struct {
+	SourceRegion string
+	SourceAmiId  string
+
+	// SelectorQuery resolves the source AMI when SourceAmiId is empty. The
+	// newest matching image (by creation time) is used.
+	SelectorQuery *ec2.DescribeImagesInput
+
+	// TargetRegions to copy into. Empty means every configured region
+	// except SourceRegion.
+	TargetRegions []string
+
+	// RegionKeyIds maps a target region to the KMS key id used to
+	// re-encrypt its copy.
+	RegionKeyIds map[string]string
+
+	// EncryptBootVolume forces the boot volume encryption state of the
+	// copy. Nil preserves the source AMI's encryption state, except in a
+	// target region with a key in RegionKeyIds, whose copy is encrypted.
+	EncryptBootVolume *bool
+
+	Name        string
+	Description string
+
+	// WaitForCompletion blocks until each region's copy leaves the
+	// pending state before Copy returns.
+	WaitForCompletion bool
+}
+
+// Copy fans out CopyImage calls for the AMI described by input across
+// input.TargetRegions, mirroring the goroutine/WaitGroup/multierror pattern
+// used by MultiImages. It returns the new AMI id created in each target
+// region, plus any per-region errors aggregated via go-multierror.
+func (a *AwsImages) Copy(input *CopyInput) (map[string]string, error) {
+	sourceSvc, ok := a.services.regions[input.SourceRegion]
+	if !ok {
+		return nil, fmt.Errorf("source region %q is not configured", input.SourceRegion)
+	}
+
+	sourceImage, err := resolveSourceImage(sourceSvc, input)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := input.TargetRegions
+	if len(targets) == 0 {
+		for _, region := range a.regions {
+			if region != input.SourceRegion {
+				targets = append(targets, region)
+			}
+		}
+	}
+
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+
+		multiErrors error
+	)
+
+	results := make(map[string]string)
+
+	for _, region := range targets {
+		wg.Add(1)
+		go func(region string) {
+			defer wg.Done()
+
+			newAmiId, err := a.copyToRegion(sourceSvc, sourceImage, input, region)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				if a.conf.AllowPartialRegions {
+					log.Printf("[WARN] region %q: %s", region, err)
+				} else {
+					multiErrors = multierror.Append(multiErrors, fmt.Errorf("region %q: %s", region, err))
+				}
+				return
+			}
+
+			results[region] = newAmiId
+		}(region)
+	}
+
+	wg.Wait()
+
+	return results, multiErrors
+}
+
+// resolveSourceImage returns the AMI named by input.SourceAmiId, or if that
+// is empty, the newest AMI matched by input.SelectorQuery.
+func resolveSourceImage(sourceSvc ec2iface.EC2API, input *CopyInput) (*ec2.Image, error) {
+	if input.SourceAmiId != "" {
+		resp, err := sourceSvc.DescribeImages(&ec2.DescribeImagesInput{
+			ImageIds: stringSlice(input.SourceAmiId),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if len(resp.Images) == 0 {
+			return nil, fmt.Errorf("AMI %q not found in region %q", input.SourceAmiId, input.SourceRegion)
+		}
+
+		return resp.Images[0], nil
+	}
+
+	if input.SelectorQuery == nil {
+		return nil, errors.New("either SourceAmiId or SelectorQuery must be set")
+	}
+
+	resp, err := sourceSvc.DescribeImages(input.SelectorQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Images) == 0 {
+		return nil, errors.New("no AMI matched the given selector query")
+	}
+
+	if len(resp.Images) > 1 {
+		sort.Sort(byTime(resp.Images))
+	}
+
+	return resp.Images[len(resp.Images)-1], nil
+}
+
+func (a *AwsImages) copyToRegion(sourceSvc ec2iface.EC2API, sourceImage *ec2.Image, input *CopyInput, region string) (string, error) {
+	destSvc, ok := a.services.regions[region]
+	if !ok {
+		return "", fmt.Errorf("target region %q is not configured", region)
+	}
+
+	copyInput := &ec2.CopyImageInput{
+		SourceRegion:  aws.String(input.SourceRegion),
+		SourceImageId: sourceImage.ImageId,
+		Name:          sourceImage.Name,
+		Description:   sourceImage.Description,
+	}
+
+	if input.Name != "" {
+		copyInput.Name = aws.String(input.Name)
+	}
+
+	if input.Description != "" {
+		copyInput.Description = aws.String(input.Description)
+	}
+
+	if input.EncryptBootVolume != nil {
+		copyInput.Encrypted = input.EncryptBootVolume
+	}
+
+	if keyId := input.RegionKeyIds[region]; keyId != "" {
+		copyInput.KmsKeyId = aws.String(keyId)
+
+		// CopyImage requires Encrypted=true whenever KmsKeyId is set; only
+		// force it when the caller hasn't made an explicit choice.
+		if input.EncryptBootVolume == nil {
+			copyInput.Encrypted = aws.Bool(true)
+		}
+	}
+
+	resp, err := destSvc.CopyImage(copyInput)
+	if err != nil {
+		return "", err
+	}
+
+	newAmiId := *resp.ImageId
+
+	// The destination snapshots exist as soon as CopyImage returns, so tags
+	// are propagated regardless of WaitForCompletion.
+	if err := copySnapshotTags(sourceSvc, destSvc, sourceImage, newAmiId); err != nil {
+		return newAmiId, err
+	}
+
+	if !input.WaitForCompletion {
+		return newAmiId, nil
+	}
+
+	if err := waitUntilImageAvailable(destSvc, newAmiId); err != nil {
+		return newAmiId, err
+	}
+
+	return newAmiId, nil
+}
+
+// waitUntilImageAvailable polls DescribeImages until amiId leaves the
+// pending state.
+func waitUntilImageAvailable(svc ec2iface.EC2API, amiId string) error {
+	for {
+		resp, err := svc.DescribeImages(&ec2.DescribeImagesInput{
+			ImageIds: stringSlice(amiId),
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(resp.Images) == 0 {
+			return fmt.Errorf("AMI %q disappeared while waiting for copy to complete", amiId)
+		}
+
+		switch *resp.Images[0].State {
+		case ec2.ImageStatePending:
+			time.Sleep(copyPollInterval)
+		case ec2.ImageStateFailed:
+			return fmt.Errorf("copy of AMI %q failed", amiId)
+		default:
+			return nil
+		}
+	}
+}
+
+// copySnapshotTags copies the tags of each source AMI snapshot onto the
+// matching snapshot of the destination AMI (matched by device name), so
+// downstream list/delete commands see consistent metadata.
+func copySnapshotTags(sourceSvc, destSvc ec2iface.EC2API, sourceImage *ec2.Image, destAmiId string) error {
+	destResp, err := destSvc.DescribeImages(&ec2.DescribeImagesInput{
+		ImageIds: stringSlice(destAmiId),
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(destResp.Images) == 0 {
+		return fmt.Errorf("copied AMI %q not found", destAmiId)
+	}
+
+	destSnapshotByDevice := make(map[string]string)
+	for _, bdm := range destResp.Images[0].BlockDeviceMappings {
+		if bdm.Ebs != nil && bdm.Ebs.SnapshotId != nil {
+			destSnapshotByDevice[*bdm.DeviceName] = *bdm.Ebs.SnapshotId
+		}
+	}
+
+	for _, bdm := range sourceImage.BlockDeviceMappings {
+		if bdm.Ebs == nil || bdm.Ebs.SnapshotId == nil {
+			continue
+		}
+
+		destSnapshotId, ok := destSnapshotByDevice[*bdm.DeviceName]
+		if !ok {
+			continue
+		}
+
+		snapResp, err := sourceSvc.DescribeSnapshots(&ec2.DescribeSnapshotsInput{
+			SnapshotIds: stringSlice(*bdm.Ebs.SnapshotId),
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(snapResp.Snapshots) == 0 || len(snapResp.Snapshots[0].Tags) == 0 {
+			continue
+		}
+
+		if _, err := destSvc.CreateTags(&ec2.CreateTagsInput{
+			Resources: stringSlice(destSnapshotId),
+			Tags:      snapResp.Snapshots[0].Tags,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
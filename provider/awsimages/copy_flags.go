@@ -0,0 +1,33 @@
+package awsimages
+
+type copyFlags struct {
+	helpMsg string
+}
+
+func newCopyFlags() *copyFlags {
+	return &copyFlags{
+		helpMsg: `Usage: images copy --provider aws [options]
+
+ Copy an AMI into one or more regions, re-encrypting EBS snapshots with a
+ per-region KMS key where requested.
+
+Options:
+	-source-region   "..."        Region the source AMI lives in
+	-source-ami      "..."        AMI id to copy
+	-target-region   "..."        Region to copy into, repeatable. Defaults
+	                               to every configured region except the
+	                               source region
+	-region-key      "region=id"  KMS key to use for a target region's
+	                               copy, repeatable
+	-encrypt         "true|false" Force the boot volume encryption state
+	                               of the copy. Unset preserves the
+	                               source AMI's encryption state, except
+	                               in a region given a -region-key, whose
+	                               copy is encrypted
+	-name            "..."        Name to give the copied AMI
+	-description     "..."        Description to give the copied AMI
+	-wait                          Block until every copy leaves the
+	                               pending state
+`,
+	}
+}
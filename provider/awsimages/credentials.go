@@ -0,0 +1,56 @@
+package awsimages
+
+import (
+	"github.com/awslabs/aws-sdk-go/aws"
+	"github.com/awslabs/aws-sdk-go/aws/credentials"
+	"github.com/awslabs/aws-sdk-go/aws/credentials/ec2rolecreds"
+	"github.com/awslabs/aws-sdk-go/aws/credentials/stscreds"
+	"github.com/awslabs/aws-sdk-go/aws/ec2metadata"
+	"github.com/awslabs/aws-sdk-go/aws/session"
+)
+
+// resolveCredentials builds a credentials chain out of conf, trying, in
+// order: static access/secret keys, a named profile from
+// ~/.aws/credentials, environment variables, and EC2 instance metadata
+// (skipped entirely when conf.SkipMetadataAPICheck is set). If
+// conf.AssumeRoleARN is set, the resolved credentials are used to assume
+// that role and the temporary role credentials are returned instead.
+func resolveCredentials(conf *AwsConfig, sess *session.Session) *credentials.Credentials {
+	providers := []credentials.Provider{
+		&credentials.StaticProvider{Value: credentials.Value{
+			AccessKeyID:     conf.AccessKey,
+			SecretAccessKey: conf.SecretKey,
+		}},
+		&credentials.SharedCredentialsProvider{Profile: conf.Profile},
+		&credentials.EnvProvider{},
+	}
+
+	if !conf.SkipMetadataAPICheck {
+		providers = append(providers, &ec2rolecreds.EC2RoleProvider{
+			Client: ec2metadata.New(sess),
+		})
+	}
+
+	creds := credentials.NewChainCredentials(providers)
+
+	if conf.AssumeRoleARN == "" {
+		return creds
+	}
+
+	stsSession := sess.Copy(&aws.Config{Credentials: creds})
+
+	return stscreds.NewCredentials(stsSession, conf.AssumeRoleARN, func(p *stscreds.AssumeRoleProvider) {
+		if conf.SessionName != "" {
+			p.RoleSessionName = conf.SessionName
+		}
+
+		if conf.ExternalID != "" {
+			p.ExternalID = aws.String(conf.ExternalID)
+		}
+
+		if conf.MFASerial != "" {
+			p.SerialNumber = aws.String(conf.MFASerial)
+			p.TokenCode = aws.String(conf.MFACode)
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package awsimages
+
+import (
+	"strings"
+
+	"github.com/awslabs/aws-sdk-go/service/ec2"
+	"github.com/awslabs/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/gobwas/glob"
+)
+
+// bootstrapRegion is used to reach the EC2 API for region discovery before
+// we know which regions the caller actually wants.
+const bootstrapRegion = "us-east-1"
+
+// ec2Factory builds an EC2 API client for the given region. Tests
+// substitute a fake via WithEC2Factory instead of hitting real AWS.
+type ec2Factory func(region string) ec2iface.EC2API
+
+// multiRegion holds one EC2 client per configured AWS region.
+type multiRegion struct {
+	regions map[string]ec2iface.EC2API
+}
+
+// newMultiRegion builds an EC2 client for every region in regions via
+// factory.
+func newMultiRegion(regions []string, factory ec2Factory) *multiRegion {
+	m := &multiRegion{
+		regions: make(map[string]ec2iface.EC2API, len(regions)),
+	}
+
+	for _, region := range regions {
+		m.regions[region] = factory(region)
+	}
+
+	return m
+}
+
+// parseRegions resolves the final region list out of the raw Region and
+// RegionExclude config values. Either may be a comma separated list mixing
+// exact region names and glob patterns (e.g. "us-*", "eu-west-?"). Region
+// being empty or "all" triggers a live DescribeRegions call to discover
+// every region in the partition.
+func parseRegions(factory ec2Factory, region, regionExclude string) ([]string, error) {
+	included, err := expandRegionList(factory, region)
+	if err != nil {
+		return nil, err
+	}
+
+	if regionExclude == "" {
+		return included, nil
+	}
+
+	excluded, err := compileGlobs(regionExclude)
+	if err != nil {
+		return nil, err
+	}
+
+	final := make([]string, 0, len(included))
+	for _, r := range included {
+		if !matchesAny(excluded, r) {
+			final = append(final, r)
+		}
+	}
+
+	return final, nil
+}
+
+func expandRegionList(factory ec2Factory, region string) ([]string, error) {
+	region = strings.TrimSpace(region)
+
+	if region == "" || region == "all" {
+		return discoverRegions(factory)
+	}
+
+	if !strings.ContainsAny(region, "*?[{") {
+		return splitRegions(region), nil
+	}
+
+	all, err := discoverRegions(factory)
+	if err != nil {
+		return nil, err
+	}
+
+	patterns, err := compileGlobs(region)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]string, 0, len(all))
+	for _, r := range all {
+		if matchesAny(patterns, r) {
+			matched = append(matched, r)
+		}
+	}
+
+	return matched, nil
+}
+
+// discoverRegions lists every region enabled for the account via
+// DescribeRegions, using a bootstrap client in bootstrapRegion.
+func discoverRegions(factory ec2Factory) ([]string, error) {
+	svc := factory(bootstrapRegion)
+
+	resp, err := svc.DescribeRegions(&ec2.DescribeRegionsInput{})
+	if err != nil {
+		return nil, err
+	}
+
+	regions := make([]string, 0, len(resp.Regions))
+	for _, r := range resp.Regions {
+		regions = append(regions, *r.RegionName)
+	}
+
+	return regions, nil
+}
+
+func splitRegions(region string) []string {
+	parts := strings.Split(region, ",")
+	regions := make([]string, 0, len(parts))
+
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			regions = append(regions, p)
+		}
+	}
+
+	return regions
+}
+
+func compileGlobs(patterns string) ([]glob.Glob, error) {
+	globs := make([]glob.Glob, 0)
+
+	for _, p := range splitRegions(patterns) {
+		g, err := glob.Compile(p)
+		if err != nil {
+			return nil, err
+		}
+
+		globs = append(globs, g)
+	}
+
+	return globs, nil
+}
+
+func matchesAny(globs []glob.Glob, region string) bool {
+	for _, g := range globs {
+		if g.Match(region) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,89 @@
+package awsimages
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/awslabs/aws-sdk-go/aws/awserr"
+)
+
+const (
+	defaultMaxRetries = 5
+	defaultBaseDelay  = 200 * time.Millisecond
+	defaultMaxDelay   = 30 * time.Second
+)
+
+// retryableErrorCodes are the AWS error codes worth backing off and
+// retrying on; anything else is treated as a permanent failure.
+var retryableErrorCodes = map[string]bool{
+	"RequestLimitExceeded": true,
+	"Throttling":           true,
+	"InternalError":        true,
+}
+
+// withRetry calls fn, retrying with exponential backoff plus jitter when it
+// fails with one of retryableErrorCodes, up to conf's MaxRetries.
+// MaxRetries/BaseDelay/MaxDelay of zero fall back to sane defaults; a
+// negative MaxRetries is clamped to zero extra retries rather than skipping
+// the call to fn altogether.
+func withRetry(conf *AwsConfig, fn func() error) error {
+	maxRetries := conf.MaxRetries
+	switch {
+	case maxRetries < 0:
+		maxRetries = 0
+	case maxRetries == 0:
+		maxRetries = defaultMaxRetries
+	}
+
+	baseDelay := conf.BaseDelay
+	if baseDelay == 0 {
+		baseDelay = defaultBaseDelay
+	}
+
+	maxDelay := conf.MaxDelay
+	if maxDelay == 0 {
+		maxDelay = defaultMaxDelay
+	}
+
+	var err error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		err = fn()
+		if err == nil {
+			return nil
+		}
+
+		if !isRetryableError(err) || attempt == maxRetries {
+			return err
+		}
+
+		time.Sleep(backoffDelay(attempt, baseDelay, maxDelay))
+	}
+
+	return err
+}
+
+func isRetryableError(err error) bool {
+	awsErr, ok := err.(awserr.Error)
+	if !ok {
+		return false
+	}
+
+	return retryableErrorCodes[awsErr.Code()]
+}
+
+// backoffDelay returns a full-jitter exponential backoff duration for the
+// given (zero-indexed) attempt, as recommended by the AWS architecture blog.
+// The exponential growth is clamped to maxDelay in the float64 domain,
+// before it's converted to a time.Duration, so a large attempt can't
+// overflow int64 nanoseconds into a negative duration.
+func backoffDelay(attempt int, baseDelay, maxDelay time.Duration) time.Duration {
+	delayFloat := float64(baseDelay) * math.Pow(2, float64(attempt))
+	if delayFloat > float64(maxDelay) {
+		delayFloat = float64(maxDelay)
+	}
+
+	delay := time.Duration(delayFloat)
+
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}